@@ -1,7 +1,12 @@
 package peco
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -177,27 +182,156 @@ func (u UserPrompt) Draw() {
 		}
 	}
 
-	width, _ := screen.Size()
-
 	pmsg := fmt.Sprintf("%s [%d/%d]", u.Matcher().String(), u.currentPage.index, u.maxPage)
-	printScreen(width-runewidth.StringWidth(pmsg), location, u.config.Style.BasicFG(), u.config.Style.BasicBG(), pmsg, false)
+	fg := u.config.Style.BasicFG()
+	bg := u.config.Style.BasicBG()
+
+	switch normalizedInfoStyle(u.config.InfoStyle) {
+	case "hidden":
+		// pager indicator suppressed entirely
+	case "inline":
+		qs := u.QueryString()
+		ql := runewidth.StringWidth(qs)
+		printScreen(u.prefixLen+1+ql+2, location, fg, bg, pmsg, false)
+	default: // "default": own line, just below (top-down) or above (bottom-up) the prompt
+		width, _ := screen.Size()
+		printScreen(width-runewidth.StringWidth(pmsg), location+u.infoLineOffset(), fg, bg, pmsg, false)
+	}
+}
+
+// infoLineOffset returns the line offset, relative to the prompt's
+// own line, at which the pager indicator is drawn when InfoStyle is
+// "default"
+func (u UserPrompt) infoLineOffset() int {
+	if u.anchor == AnchorTop {
+		return 1
+	}
+	return -1
+}
+
+// normalizedInfoStyle maps an InfoStyle config value to one of
+// "default", "inline" or "hidden"
+func normalizedInfoStyle(s string) string {
+	switch s {
+	case "inline", "hidden":
+		return s
+	default:
+		return "default"
+	}
+}
+
+// infoLineReserved returns 1 if InfoStyle "default" needs its own
+// reserved row (besides the prompt), and 0 otherwise ("inline" and
+// "hidden" don't use a row of their own)
+func infoLineReserved(ctx *Ctx) int {
+	if normalizedInfoStyle(ctx.config.InfoStyle) == "default" {
+		return 1
+	}
+	return 0
+}
+
+// headerHeight returns the number of rows that should be reserved
+// for the sticky header, based on config.HeaderString and
+// config.HeaderLines. Returns 0 if no header is configured
+func headerHeight(ctx *Ctx) int {
+	if ctx.config.HeaderString != "" {
+		return 1
+	}
+	if ctx.config.HeaderLines > 0 {
+		return ctx.config.HeaderLines
+	}
+	return 0
 }
 
+// HeaderArea draws the sticky header lines reserved via
+// config.HeaderLines (or the static config.HeaderString), fzf's
+// --header-lines style. Unlike ListArea, its contents never scroll
+//
+// NOTE: config.HeaderLines is only half-wired up in this tree. fzf's
+// behavior (and the intent here) is for the reader/buffer layer to
+// strip its first HeaderLines lines out of the filterable/selectable
+// match pool entirely, leaving HeaderArea to paint them separately.
+// That stripping has to happen where matches are read in, not here --
+// targets passed to ListArea/BasicLayout are the post-filter match
+// list, already indexed by l.currentLine/l.current, so truncating
+// them at draw time desyncs the cursor from what's highlighted and
+// (once a query is active) drops arbitrary real matches instead of
+// the header. Until the reader/buffer side does the stripping,
+// HeaderLines rows will keep showing up twice: once here, and again
+// as an ordinary row in ListArea
+type HeaderArea struct {
+	*Ctx
+	*AnchorSettings
+}
+
+// NewHeaderArea creates a new HeaderArea struct. Returns nil if no
+// header has been configured
+func NewHeaderArea(ctx *Ctx, anchor VerticalAnchor, anchorOffset int) *HeaderArea {
+	if headerHeight(ctx) <= 0 {
+		return nil
+	}
+
+	return &HeaderArea{
+		Ctx:            ctx,
+		AnchorSettings: NewAnchorSettings(anchor, anchorOffset),
+	}
+}
+
+// Height returns the number of rows this header reserves
+func (h *HeaderArea) Height() int {
+	return headerHeight(h.Ctx)
+}
+
+// Draw renders the header in a distinct style. It never scrolls and
+// is not subject to the current query or page
+func (h *HeaderArea) Draw() {
+	fg := h.config.Style.HeaderFG()
+	bg := h.config.Style.HeaderBG()
+	start := h.AnchorPosition()
+
+	if h.config.HeaderString != "" {
+		printScreen(0, start, fg, bg, h.config.HeaderString, true)
+		return
+	}
+
+	for n, line := range h.Header() {
+		printScreen(0, start+n, fg, bg, line, true)
+	}
+}
+
+// defaultSpinnerGlyphs are used when config.SpinnerGlyphs is empty
+var defaultSpinnerGlyphs = []string{"-", "\\", "|", "/"}
+
+// spinnerInterval is how often the spinner glyph advances while
+// input is still being read
+const spinnerInterval = 100 * time.Millisecond
+
+// counterWidth is the number of columns reserved on the right side of
+// the status bar row for the reading spinner / match counter, so it
+// never collides with a message printed via PrintStatus
+const counterWidth = 20
+
 // StatusBar draws the status message bar
 type StatusBar struct {
 	*Ctx
 	*AnchorSettings
 	clearTimer *time.Timer
 	timerMutex *sync.Mutex
+
+	reading    bool
+	matched    int
+	total      int
+	spinnerIdx int
+	ticker     *time.Ticker
+	tickerDone chan struct{}
 }
 
 // NewStatusBar creates a new StatusBar struct
 func NewStatusBar(ctx *Ctx, anchor VerticalAnchor, anchorOffset int) *StatusBar {
 	return &StatusBar{
-		ctx,
-		NewAnchorSettings(anchor, anchorOffset),
-		nil,
-		&sync.Mutex{},
+		Ctx:            ctx,
+		AnchorSettings: NewAnchorSettings(anchor, anchorOffset),
+		timerMutex:     &sync.Mutex{},
 	}
 }
 
@@ -210,8 +344,11 @@ func (s *StatusBar) stopTimer() {
 	}
 }
 
-// PrintStatus prints a new status message. This also resets the
-// timer created by ClearStatus()
+// PrintStatus prints a new status message in the left "message"
+// region of the status bar. This also resets the timer created by
+// ClearStatus(). It never overwrites the reading spinner / counter,
+// which occupies the reserved region on the right and is painted
+// independently by drawCounter
 func (s *StatusBar) PrintStatus(msg string, clearDelay time.Duration) {
 	s.stopTimer()
 
@@ -220,17 +357,22 @@ func (s *StatusBar) PrintStatus(msg string, clearDelay time.Duration) {
 	location := s.AnchorPosition()
 
 	w, _ := screen.Size()
+	msgWidth := w - counterWidth
+	if msgWidth < 0 {
+		msgWidth = 0
+	}
+
 	width := runewidth.StringWidth(msg)
-	for width > w {
+	for width > msgWidth {
 		_, rw := utf8.DecodeRuneInString(msg)
 		width = width - rw
 		msg = msg[rw:]
 	}
 
 	var pad []byte
-	if w > width {
-		pad = make([]byte, w-width)
-		for i := 0; i < w-width; i++ {
+	if msgWidth > width {
+		pad = make([]byte, msgWidth-width)
+		for i := 0; i < msgWidth-width; i++ {
 			pad[i] = ' '
 		}
 	}
@@ -238,12 +380,12 @@ func (s *StatusBar) PrintStatus(msg string, clearDelay time.Duration) {
 	fgAttr := s.config.Style.BasicFG()
 	bgAttr := s.config.Style.BasicBG()
 
-	if w > width {
+	if msgWidth > width {
 		printScreen(0, location, fgAttr, bgAttr, string(pad), false)
 	}
 
 	if width > 0 {
-		printScreen(w-width, location, fgAttr|termbox.AttrReverse|termbox.AttrBold, bgAttr|termbox.AttrReverse, msg, false)
+		printScreen(msgWidth-width, location, fgAttr|termbox.AttrReverse|termbox.AttrBold, bgAttr|termbox.AttrReverse, msg, false)
 	}
 	screen.Flush()
 
@@ -258,32 +400,300 @@ func (s *StatusBar) PrintStatus(msg string, clearDelay time.Duration) {
 	}
 }
 
+// spinnerGlyphs returns the glyph set the spinner cycles through
+func (s *StatusBar) spinnerGlyphs() []string {
+	g := s.config.SpinnerGlyphs
+	if len(g) == 0 {
+		g = defaultSpinnerGlyphs
+	}
+	return g
+}
+
+// SetReading turns the background-reading spinner on or off, starting
+// (or stopping) the ticker that drives its animation
+func (s *StatusBar) SetReading(reading bool) {
+	s.timerMutex.Lock()
+	s.reading = reading
+
+	if !reading {
+		s.stopTickerLocked()
+		s.timerMutex.Unlock()
+		s.drawCounter()
+		return
+	}
+
+	if s.ticker != nil {
+		s.timerMutex.Unlock()
+		return
+	}
+
+	s.ticker = time.NewTicker(spinnerInterval)
+	done := make(chan struct{})
+	s.tickerDone = done
+	ticker := s.ticker
+	s.timerMutex.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.timerMutex.Lock()
+				s.spinnerIdx++
+				s.timerMutex.Unlock()
+				s.drawCounter()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// stopTickerLocked stops the spinner ticker, if any. s.timerMutex
+// must be held by the caller
+func (s *StatusBar) stopTickerLocked() {
+	if s.ticker == nil {
+		return
+	}
+	s.ticker.Stop()
+	close(s.tickerDone)
+	s.ticker = nil
+	s.tickerDone = nil
+}
+
+// SetCounts updates the matched/total counters shown alongside the
+// spinner
+func (s *StatusBar) SetCounts(matched, total int) {
+	s.timerMutex.Lock()
+	s.matched = matched
+	s.total = total
+	s.timerMutex.Unlock()
+	s.drawCounter()
+}
+
+// Stop cancels the spinner ticker, if any, so its goroutine does not
+// leak past shutdown
+func (s *StatusBar) Stop() {
+	s.timerMutex.Lock()
+	defer s.timerMutex.Unlock()
+	s.stopTickerLocked()
+}
+
+// counterMessage formats the right-hand status bar indicator, e.g.
+// " 12345/- " while reading with an unknown total, or " 12345/98765 "
+// once the total is known
+func (s *StatusBar) counterMessage() string {
+	s.timerMutex.Lock()
+	reading := s.reading
+	matched := s.matched
+	total := s.total
+	idx := s.spinnerIdx
+	s.timerMutex.Unlock()
+
+	if !reading && total == 0 {
+		return ""
+	}
+
+	if total > 0 {
+		return fmt.Sprintf(" %d/%d ", matched, total)
+	}
+
+	glyphs := s.spinnerGlyphs()
+	return fmt.Sprintf(" %d/%s ", matched, glyphs[idx%len(glyphs)])
+}
+
+// drawCounter paints the right-aligned reading/progress indicator.
+// It shares the status bar row with PrintStatus's message, which is
+// confined to the left counterWidth-narrower region
+func (s *StatusBar) drawCounter() {
+	msg := s.counterMessage()
+
+	location := s.AnchorPosition()
+	w, _ := screen.Size()
+	x := w - counterWidth
+	if x < 0 {
+		x = 0
+	}
+
+	fgAttr := s.config.Style.BasicFG()
+	bgAttr := s.config.Style.BasicBG()
+
+	printScreen(x, location, fgAttr, bgAttr, msg, true)
+	screen.Flush()
+}
+
+// JumpMode describes the state of fzf-style jump-label navigation
+// in a ListArea
+type JumpMode int
+
+const (
+	// JumpDisabled means jump mode is off; ListArea draws normally
+	JumpDisabled JumpMode = iota
+	// JumpEnabled means the next keypress moves the cursor to the
+	// labeled line
+	JumpEnabled
+	// JumpAcceptEnabled is like JumpEnabled, but also accepts the
+	// current state once the cursor has moved
+	JumpAcceptEnabled
+)
+
+// defaultJumpLabels is used when config.JumpLabels is not set
+const defaultJumpLabels = "asdfghjkl;"
+
+// jumpLabelWidth is the number of columns reserved at the start of
+// each row for the jump label while jump mode is active
+const jumpLabelWidth = 2
+
 // ListArea represents the area where the actual line buffer is
 // displayed in the screen
 type ListArea struct {
 	*Ctx
 	*AnchorSettings
 	sortTopDown bool
+	xscroll     int // additional user-requested horizontal scroll, in cells
+	labeled     int // number of rows labeled for jump mode in the last Draw
 }
 
 // NewListArea creates a new ListArea struct
 func NewListArea(ctx *Ctx, anchor VerticalAnchor, anchorOffset int, sortTopDown bool) *ListArea {
 	return &ListArea{
-		ctx,
-		NewAnchorSettings(anchor, anchorOffset),
-		sortTopDown,
+		Ctx:            ctx,
+		AnchorSettings: NewAnchorSettings(anchor, anchorOffset),
+		sortTopDown:    sortTopDown,
+	}
+}
+
+// hscrollStep is how many cells ToScrollLeft/ToScrollRight move the
+// view per keypress
+const hscrollStep = 4
+
+// ScrollLeft nudges the horizontally-scrolled window to the left
+func (l *ListArea) ScrollLeft() {
+	l.xscroll -= hscrollStep
+	if l.xscroll < 0 {
+		l.xscroll = 0
+	}
+}
+
+// ScrollRight nudges the horizontally-scrolled window to the right
+func (l *ListArea) ScrollRight() {
+	l.xscroll += hscrollStep
+}
+
+// jumpLabels returns the alphabet used to label visible rows while
+// jump mode is active
+func (l *ListArea) jumpLabels() []rune {
+	alphabet := l.config.JumpLabels
+	if alphabet == "" {
+		alphabet = defaultJumpLabels
+	}
+	return []rune(alphabet)
+}
+
+// JumpIndex returns the (1-base) line number labeled r on the
+// currently displayed page, and whether r is a valid label. Only
+// labels actually drawn during the last Draw (l.labeled) are
+// considered, so a short last page can't jump past the real end of
+// the list
+func (l *ListArea) JumpIndex(r rune) (int, bool) {
+	for i, label := range l.jumpLabels() {
+		if i >= l.labeled {
+			break
+		}
+		if label == r {
+			return l.currentPage.offset + i + 1, true
+		}
 	}
+	return 0, false
 }
 
-// Draw displays the ListArea on the screen
-func (l *ListArea) Draw(targets []Match, perPage int) {
+// defaultHScrollOff is used when config.HScrollOff is not set (or
+// is non-positive)
+const defaultHScrollOff = 10
+
+// hscrollEllipsis marks a cell where content has been clipped off by
+// horizontal scrolling
+const hscrollEllipsis = ".."
+
+// columnOffsets maps every byte offset in line to the terminal column
+// at which it starts (accounting for double-width runes), so that
+// Indices() byte ranges can be translated into visible column
+// positions. offsets[len(line)] holds the line's total width
+func columnOffsets(line string) []int {
+	offsets := make([]int, len(line)+1)
+	col := 0
+	for i := 0; i < len(line); {
+		r, w := utf8.DecodeRuneInString(line[i:])
+		for j := 0; j < w; j++ {
+			offsets[i+j] = col
+		}
+		col += runewidth.RuneWidth(r)
+		i += w
+	}
+	offsets[len(line)] = col
+	return offsets
+}
+
+// byteIndexForColumn returns the smallest byte offset whose column
+// (per offsets, as returned by columnOffsets) is >= col
+func byteIndexForColumn(offsets []int, col int) int {
+	for i, c := range offsets {
+		if c >= col {
+			return i
+		}
+	}
+	return len(offsets) - 1
+}
+
+// clipMatches restricts matches (byte ranges) to the [lo, hi) byte
+// window, dropping or truncating ranges that fall outside of it
+func clipMatches(matches [][]int, lo, hi int) [][]int {
+	if matches == nil {
+		return nil
+	}
+
+	var out [][]int
+	for _, m := range matches {
+		if m[1] <= lo || m[0] >= hi {
+			continue
+		}
+		start, end := m[0], m[1]
+		if start < lo {
+			start = lo
+		}
+		if end > hi {
+			end = hi
+		}
+		out = append(out, []int{start, end})
+	}
+	return out
+}
+
+// Draw displays the ListArea on the screen, confined to the column
+// window [xorigin, xorigin+width), which may be narrower than the
+// full screen width when a left/right preview pane shares the row
+func (l *ListArea) Draw(targets []Match, perPage, xorigin, width int) {
 	currentPage := l.currentPage
 
 	start := l.AnchorPosition()
 
-	var y int
+	xoffset := xorigin
+	var labels []rune
+	if l.jumping != JumpDisabled {
+		xoffset += jumpLabelWidth
+		labels = l.jumpLabels()
+	}
+
+	availWidth := width - (xoffset - xorigin)
+
+	hscrollOff := l.config.HScrollOff
+	if hscrollOff <= 0 {
+		hscrollOff = defaultHScrollOff
+	}
+
+	row := 0
+	labeled := 0
 	var fgAttr, bgAttr termbox.Attribute
-	for n := 0; n < perPage; n++ {
+	for n := 0; row < perPage && n < perPage; n++ {
 		switch {
 		case n+currentPage.offset == l.currentLine-1:
 			fgAttr = l.config.Style.SelectedFG()
@@ -301,40 +711,387 @@ func (l *ListArea) Draw(targets []Match, perPage int) {
 			break
 		}
 
-		if l.sortTopDown {
-			y = n + start
-		} else {
-			y = start - n
+		y := l.rowY(start, row)
+		if n < len(labels) {
+			printScreen(xorigin, y, l.config.Style.JumpLabelFG(), l.config.Style.JumpLabelBG(), string(labels[n]), false)
+			labeled = n + 1
 		}
 
 		target := targets[targetIdx]
 		line := target.Line()
 		matches := target.Indices()
-		if matches == nil {
-			printScreen(0, y, fgAttr, bgAttr, line, true)
-		} else {
-			prev := 0
-			index := 0
-			for _, m := range matches {
-				if m[0] > index {
-					c := line[index:m[0]]
-					printScreen(prev, y, fgAttr, bgAttr, c, false)
-					prev += runewidth.StringWidth(c)
-					index += len(c)
-				}
-				c := line[m[0]:m[1]]
-				printScreen(prev, y, l.config.Style.MatchedFG(), mergeAttribute(bgAttr, l.config.Style.MatchedBG()), c, true)
-				prev += runewidth.StringWidth(c)
-				index += len(c)
-			}
 
-			m := matches[len(matches)-1]
-			if m[0] > index {
-				printScreen(prev, y, l.config.Style.QueryFG(), mergeAttribute(bgAttr, l.config.Style.QueryBG()), line[m[0]:m[1]], true)
-			} else if len(line) > m[1] {
-				printScreen(prev, y, fgAttr, bgAttr, line[m[1]:len(line)], true)
-			}
+		right := xorigin + width
+		switch {
+		case runewidth.StringWidth(line) <= availWidth:
+			l.drawSegment(xoffset, y, fgAttr, bgAttr, line, matches, 0, len(line), true, right)
+			row++
+		case l.config.WrapLongLines:
+			row += l.drawWrapped(xoffset, right, start, row, perPage, fgAttr, bgAttr, line, matches, availWidth)
+		case l.config.HScroll:
+			l.drawScrolled(xoffset, right, y, fgAttr, bgAttr, line, matches, availWidth, hscrollOff)
+			row++
+		default:
+			l.drawSegment(xoffset, y, fgAttr, bgAttr, line, matches, 0, len(line), true, right)
+			row++
+		}
+	}
+
+	l.labeled = labeled
+}
+
+// rowY translates a visual row index into a screen y coordinate,
+// honoring the direction ListArea renders in
+func (l *ListArea) rowY(start, row int) int {
+	if l.sortTopDown {
+		return start + row
+	}
+	return start - row
+}
+
+// fillRow paints blank cells from x0 (inclusive) to x1 (exclusive) on
+// row y. Unlike printScreen's fill, it never spills past x1, which
+// matters once a left/right preview pane narrows the list's column
+// window
+func fillRow(x0, x1 int, y int, fgAttr, bgAttr termbox.Attribute) {
+	for x := x0; x < x1; x++ {
+		screen.SetCell(x, y, ' ', fgAttr, bgAttr)
+	}
+}
+
+// drawSegment paints the [lo, hi) byte window of line at column x,
+// highlighting any matches that fall (partially) within that window.
+// If fill is true, the remainder of the row up to (but excluding)
+// right is blanked out
+func (l *ListArea) drawSegment(x, y int, fgAttr, bgAttr termbox.Attribute, line string, matches [][]int, lo, hi int, fill bool, right int) {
+	if matches == nil {
+		c := line[lo:hi]
+		printScreen(x, y, fgAttr, bgAttr, c, false)
+		if fill {
+			fillRow(x+runewidth.StringWidth(c), right, y, fgAttr, bgAttr)
+		}
+		return
+	}
+
+	prev := x
+	index := lo
+	for _, m := range matches {
+		if m[0] > index {
+			c := line[index:m[0]]
+			printScreen(prev, y, fgAttr, bgAttr, c, false)
+			prev += runewidth.StringWidth(c)
 		}
+		c := line[m[0]:m[1]]
+		printScreen(prev, y, l.config.Style.MatchedFG(), mergeAttribute(bgAttr, l.config.Style.MatchedBG()), c, false)
+		prev += runewidth.StringWidth(c)
+		index = m[1]
+	}
+
+	if index < hi {
+		c := line[index:hi]
+		printScreen(prev, y, fgAttr, bgAttr, c, false)
+		prev += runewidth.StringWidth(c)
+	}
+	if fill {
+		fillRow(prev, right, y, fgAttr, bgAttr)
+	}
+}
+
+// drawScrolled renders a single, too-wide line in a horizontally
+// scrolled window that keeps the match at least hscrollOff cells from
+// either edge, marking clipped sides with hscrollEllipsis. right is
+// the column window's right boundary (exclusive)
+func (l *ListArea) drawScrolled(x, right, y int, fgAttr, bgAttr termbox.Attribute, line string, matches [][]int, availWidth, hscrollOff int) {
+	cols := columnOffsets(line)
+	lineWidth := cols[len(line)]
+
+	var matchStart, matchEnd int
+	if len(matches) > 0 {
+		matchStart = cols[matches[0][0]]
+		matchEnd = cols[matches[len(matches)-1][1]]
+	}
+
+	xoffset := matchStart - hscrollOff
+	if right := matchEnd - (availWidth - hscrollOff); right > xoffset {
+		xoffset = right
+	}
+	xoffset += l.xscroll
+	if xoffset < 0 {
+		xoffset = 0
+	}
+	if max := lineWidth - availWidth; xoffset > max {
+		xoffset = max
+	}
+
+	clippedLeft := xoffset > 0
+	clippedRight := xoffset+availWidth < lineWidth
+
+	segX, loCol := x, xoffset
+	if clippedLeft {
+		printScreen(segX, y, fgAttr, bgAttr, hscrollEllipsis, false)
+		segX += runewidth.StringWidth(hscrollEllipsis)
+		loCol += runewidth.StringWidth(hscrollEllipsis)
+	}
+
+	hiCol := xoffset + availWidth
+	if clippedRight {
+		hiCol -= runewidth.StringWidth(hscrollEllipsis)
+	}
+
+	lo := byteIndexForColumn(cols, loCol)
+	hi := byteIndexForColumn(cols, hiCol)
+	if hi < lo {
+		hi = lo
+	}
+
+	l.drawSegment(segX, y, fgAttr, bgAttr, line, clipMatches(matches, lo, hi), lo, hi, !clippedRight, right)
+
+	if clippedRight {
+		printScreen(x+availWidth-runewidth.StringWidth(hscrollEllipsis), y, fgAttr, bgAttr, hscrollEllipsis, false)
+	}
+}
+
+// drawWrapped renders a too-wide line across as many visual rows as
+// it takes to show it in full, starting at visual row `row`. It stops
+// before visual row perPage, so a line needing more rows than remain
+// is cut off. right is the column window's right boundary
+// (exclusive). It returns how many visual rows it consumed
+func (l *ListArea) drawWrapped(x, right, start, row, perPage int, fgAttr, bgAttr termbox.Attribute, line string, matches [][]int, availWidth int) int {
+	cols := columnOffsets(line)
+
+	drawn := 0
+	lo := 0
+	for lo < len(line) && row+drawn < perPage {
+		hi := byteIndexForColumn(cols, cols[lo]+availWidth)
+		if hi <= lo {
+			hi = len(line)
+		}
+
+		y := l.rowY(start, row+drawn)
+		l.drawSegment(x, y, fgAttr, bgAttr, line, clipMatches(matches, lo, hi), lo, hi, true, right)
+
+		lo = hi
+		drawn++
+	}
+
+	return drawn
+}
+
+// PreviewWindowPosition describes where the preview pane is drawn
+// relative to the ListArea, mirroring fzf's --preview-window
+type PreviewWindowPosition string
+
+const (
+	// PreviewWindowPositionRight draws the preview to the right of the list
+	PreviewWindowPositionRight PreviewWindowPosition = "right"
+	// PreviewWindowPositionLeft draws the preview to the left of the list
+	PreviewWindowPositionLeft PreviewWindowPosition = "left"
+	// PreviewWindowPositionTop draws the preview above the list
+	PreviewWindowPositionTop PreviewWindowPosition = "top"
+	// PreviewWindowPositionBottom draws the preview below the list
+	PreviewWindowPositionBottom PreviewWindowPosition = "bottom"
+)
+
+// previewDebounce is how long we wait for the cursor to settle before
+// spawning a new preview command
+const previewDebounce = 50 * time.Millisecond
+
+// PreviewArea draws the output of a user-supplied command run against
+// the currently highlighted match, fzf's --preview style
+type PreviewArea struct {
+	*Ctx
+	*AnchorSettings
+	position PreviewWindowPosition
+	size     string
+	wrap     bool
+
+	mutex    sync.Mutex
+	cancel   context.CancelFunc
+	debounce *time.Timer
+	lines    []string
+	scroll   int
+}
+
+// NewPreviewArea creates a new PreviewArea struct. Returns nil if no
+// preview command has been configured
+func NewPreviewArea(ctx *Ctx, anchor VerticalAnchor, anchorOffset int) *PreviewArea {
+	p := ctx.config.Preview
+	if p.Command == "" {
+		return nil
+	}
+
+	position := PreviewWindowPosition(p.Position)
+	if position == "" {
+		position = PreviewWindowPositionRight
+	}
+
+	size := p.Size
+	if size == "" {
+		size = "50%"
+	}
+
+	return &PreviewArea{
+		Ctx:            ctx,
+		AnchorSettings: NewAnchorSettings(anchor, anchorOffset),
+		position:       position,
+		size:           size,
+		wrap:           p.Wrap,
+	}
+}
+
+// reservedColumns returns how many columns of the given total width
+// the preview pane reserves, for positions that split the screen
+// left/right
+func (p *PreviewArea) reservedColumns(totalWidth int) int {
+	switch p.position {
+	case PreviewWindowPositionLeft, PreviewWindowPositionRight:
+		return p.reservedCells(totalWidth)
+	default:
+		return 0
+	}
+}
+
+// reservedRows returns how many rows of the given total height the
+// preview pane reserves, for positions that split the screen top/bottom
+func (p *PreviewArea) reservedRows(totalHeight int) int {
+	switch p.position {
+	case PreviewWindowPositionTop, PreviewWindowPositionBottom:
+		return p.reservedCells(totalHeight)
+	default:
+		return 0
+	}
+}
+
+func (p *PreviewArea) reservedCells(total int) int {
+	s := strings.TrimSpace(p.size)
+	if strings.HasSuffix(s, "%") {
+		pct, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		if err != nil || pct <= 0 {
+			return 0
+		}
+		if pct > 100 {
+			pct = 100
+		}
+		return total * pct / 100
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if n > total {
+		n = total
+	}
+	return n
+}
+
+// expandPreviewCommand expands the `{}`, `{q}` and `{n}` placeholders
+// in the configured preview command against the currently highlighted
+// match
+func expandPreviewCommand(tmpl, line, query string, n int) string {
+	r := strings.NewReplacer(
+		"{}", line,
+		"{q}", query,
+		"{n}", strconv.Itoa(n),
+	)
+	return r.Replace(tmpl)
+}
+
+// Update kicks off a (debounced) preview command run for the given
+// target. Any previous, still-running preview is canceled and its
+// output discarded
+func (p *PreviewArea) Update(target Match, query string, n int) {
+	p.mutex.Lock()
+	if p.debounce != nil {
+		p.debounce.Stop()
+	}
+	p.debounce = time.AfterFunc(previewDebounce, func() {
+		p.run(target, query, n)
+	})
+	p.mutex.Unlock()
+}
+
+func (p *PreviewArea) run(target Match, query string, n int) {
+	p.mutex.Lock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.mutex.Unlock()
+
+	command := expandPreviewCommand(p.config.Preview.Command, target.Line(), query, n)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	cmd.Wait()
+
+	if ctx.Err() != nil {
+		// a newer preview has already superseded this one
+		return
+	}
+
+	p.mutex.Lock()
+	p.lines = lines
+	p.scroll = 0
+	p.mutex.Unlock()
+}
+
+// ScrollUp scrolls the preview content up by one line
+func (p *PreviewArea) ScrollUp() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.scroll > 0 {
+		p.scroll--
+	}
+}
+
+// ScrollDown scrolls the preview content down by one line
+func (p *PreviewArea) ScrollDown() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.scroll < len(p.lines)-1 {
+		p.scroll++
+	}
+}
+
+// Draw renders the buffered preview output into the reserved pane,
+// which occupies the column window [x, x+width)
+func (p *PreviewArea) Draw(x, width, height int) {
+	p.mutex.Lock()
+	lines := p.lines
+	scroll := p.scroll
+	p.mutex.Unlock()
+
+	start := p.AnchorPosition()
+	fg := p.config.Style.BasicFG()
+	bg := p.config.Style.BasicBG()
+	right := x + width
+
+	for n := 0; n < height; n++ {
+		y := start + n
+		idx := scroll + n
+		if idx >= len(lines) {
+			fillRow(x, right, y, fg, bg)
+			continue
+		}
+		line := lines[idx]
+		if !p.wrap && runewidth.StringWidth(line) > width {
+			line = runewidth.Truncate(line, width, "")
+		}
+		printScreen(x, y, fg, bg, line, false)
+		fillRow(x+runewidth.StringWidth(line), right, y, fg, bg)
 	}
 }
 
@@ -345,38 +1102,108 @@ func (l *ListArea) Draw(targets []Match, perPage int) {
 type BasicLayout struct {
 	*Ctx
 	*StatusBar
-	prompt *UserPrompt
-	list   *ListArea
+	prompt       *UserPrompt
+	header       *HeaderArea
+	list         *ListArea
+	preview      *PreviewArea
+	previewed    string // Line() of the match currently shown in the preview
+	previewedSet bool
 }
 
 // NewDefaultLayout creates a new Layout in the default format (top-down)
 func NewDefaultLayout(ctx *Ctx) *BasicLayout {
+	hh := headerHeight(ctx)
+	ir := infoLineReserved(ctx)
+
+	listOffset := 1 + ir + hh
+	preview := NewPreviewArea(ctx, AnchorTop, listOffset)
+	if preview != nil {
+		switch preview.position {
+		case PreviewWindowPositionTop:
+			// the preview sits between the header and the list, so
+			// push the list down to make room for it
+			_, height := screen.Size()
+			listOffset += preview.reservedRows(height)
+		case PreviewWindowPositionBottom:
+			// the preview claims a strip just above the status bar;
+			// linesPerPage already excludes it from the list's budget.
+			// Draw paints downward, so anchor at the strip's top row
+			_, height := screen.Size()
+			preview.AnchorSettings = NewAnchorSettings(AnchorBottom, preview.reservedRows(height))
+		}
+	}
+
 	return &BasicLayout{
 		Ctx:       ctx,
 		StatusBar: NewStatusBar(ctx, AnchorBottom, 0),
 		// The prompt is at the top
 		prompt: NewUserPrompt(ctx, AnchorTop, 0),
-		// The list area is at the top, after the prompt
-		// It's also displayed top-to-bottom order
-		list: NewListArea(ctx, AnchorTop, 1, true),
+		// The header, if configured, is just below the prompt and its
+		// own InfoStyle "default" line, if any
+		header: NewHeaderArea(ctx, AnchorTop, 1+ir),
+		// The list area is at the top, after the prompt, info line and
+		// header. It's also displayed top-to-bottom order
+		list: NewListArea(ctx, AnchorTop, listOffset, true),
+		// The preview pane, if configured, shares the screen with the
+		// list (left/right/top) or its own row near the status bar
+		// (bottom) -- see the position switch above
+		preview: preview,
 	}
 }
 
 // NewBottomUpLayout creates a new Layout in bottom-up format
 func NewBottomUpLayout(ctx *Ctx) *BasicLayout {
+	hh := headerHeight(ctx)
+	ir := infoLineReserved(ctx)
+
+	listOffset := 2 + hh + ir
+	preview := NewPreviewArea(ctx, AnchorBottom, listOffset)
+	if preview != nil {
+		switch preview.position {
+		case PreviewWindowPositionBottom:
+			// the preview sits between the list and the prompt/header
+			// stack, so push the list further away to make room.
+			// Draw paints downward, so anchor at the strip's top row
+			_, height := screen.Size()
+			rows := preview.reservedRows(height)
+			preview.AnchorSettings = NewAnchorSettings(AnchorBottom, listOffset+rows-1)
+			listOffset += rows
+		case PreviewWindowPositionTop:
+			// the preview claims a strip at the very top of the
+			// screen; linesPerPage already excludes it from the
+			// list's budget
+			preview.AnchorSettings = NewAnchorSettings(AnchorTop, 0)
+		case PreviewWindowPositionLeft, PreviewWindowPositionRight:
+			// the list grows upward from its anchor to the top of the
+			// screen, so the side pane starts at the top as well
+			preview.AnchorSettings = NewAnchorSettings(AnchorTop, 0)
+		}
+	}
+
 	return &BasicLayout{
 		Ctx:       ctx,
 		StatusBar: NewStatusBar(ctx, AnchorBottom, 0),
 		// The prompt is at the bottom, above the status bar
 		prompt: NewUserPrompt(ctx, AnchorBottom, 1),
-		// The list area is at the bottom, above the prompt
-		// IT's displayed in bottom-to-top order
-		list: NewListArea(ctx, AnchorBottom, 2, false),
+		// The header, if configured, is just above the prompt and its
+		// own InfoStyle "default" line, if any
+		header: NewHeaderArea(ctx, AnchorBottom, 1+hh+ir),
+		// The list area is at the bottom, above the prompt, info line
+		// and header. It's displayed in bottom-to-top order
+		list: NewListArea(ctx, AnchorBottom, listOffset, false),
+		// The preview pane, if configured, shares the screen with the
+		// list (left/right/bottom) or its own row at the top of the
+		// screen (top) -- see the position switch above
+		preview: preview,
 	}
 }
 
 // CalculatePage calculates which page we're displaying
 func (l *BasicLayout) CalculatePage(targets []Match, perPage int) error {
+	if l.config.WrapLongLines {
+		return l.calculateWrappedPage(targets, perPage)
+	}
+
 CALCULATE_PAGE:
 	currentPage := l.currentPage
 	currentPage.index = ((l.currentLine - 1) / perPage) + 1
@@ -403,6 +1230,77 @@ CALCULATE_PAGE:
 	return nil
 }
 
+// visualRows returns how many visual rows line occupies once wrapped
+// to availWidth columns
+func visualRows(line string, availWidth int) int {
+	if availWidth <= 0 {
+		return 1
+	}
+	n := (runewidth.StringWidth(line) + availWidth - 1) / availWidth
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// calculateWrappedPage is CalculatePage's counterpart for
+// config.WrapLongLines: because a long match can consume more than
+// one visual row, perPage matches no longer fit in a fixed perPage
+// window, so page boundaries are found by walking targets and
+// accumulating the visual rows they'd actually take
+func (l *BasicLayout) calculateWrappedPage(targets []Match, perPage int) error {
+	currentPage := l.currentPage
+	currentPage.perPage = perPage
+
+	if len(targets) == 0 {
+		currentPage.index = 1
+		currentPage.offset = 0
+		l.maxPage = 1
+		if l.QueryLen() == 0 {
+			return fmt.Errorf("no targets or query. nothing to do")
+		}
+		return nil
+	}
+
+	width, _ := screen.Size()
+	availWidth := width
+	if l.preview != nil {
+		availWidth -= l.preview.reservedColumns(width)
+	}
+	if l.jumping != JumpDisabled {
+		availWidth -= jumpLabelWidth
+	}
+
+	pageOffsets := []int{0}
+	rows := 0
+	for i, t := range targets {
+		need := visualRows(t.Line(), availWidth)
+		if rows > 0 && rows+need > perPage {
+			pageOffsets = append(pageOffsets, i)
+			rows = 0
+		}
+		rows += need
+	}
+	l.maxPage = len(pageOffsets)
+
+	if l.currentLine <= 0 {
+		l.currentLine = 1
+	} else if l.currentLine > len(targets) {
+		l.currentLine = len(targets)
+	}
+	idx := l.currentLine - 1
+
+	currentPage.index, currentPage.offset = 1, 0
+	for page, offset := range pageOffsets {
+		if idx < offset {
+			break
+		}
+		currentPage.index, currentPage.offset = page+1, offset
+	}
+
+	return nil
+}
+
 func (l *BasicLayout) DrawPrompt() {
 	l.prompt.Draw()
 }
@@ -417,23 +1315,102 @@ func (l *BasicLayout) DrawScreen(targets []Match) {
 		l.currentLine = len(targets)
 	}
 
-	perPage := linesPerPage()
+	perPage := l.linesPerPage()
 
 	if err := l.CalculatePage(targets, perPage); err != nil {
 		return
 	}
 
 	l.DrawPrompt()
-	l.list.Draw(targets, perPage)
+	if l.header != nil {
+		l.header.Draw()
+	}
+
+	width, height := screen.Size()
+	listX, listWidth := 0, width
+	if l.preview != nil {
+		switch l.preview.position {
+		case PreviewWindowPositionLeft:
+			pw := l.preview.reservedColumns(width)
+			listX, listWidth = pw, width-pw
+		case PreviewWindowPositionRight:
+			listWidth = width - l.preview.reservedColumns(width)
+		}
+	}
+	l.list.Draw(targets, perPage, listX, listWidth)
+
+	if l.preview != nil {
+		var line string
+		var have bool
+		if idx := l.currentLine - 1; idx >= 0 && idx < len(targets) {
+			line, have = targets[idx].Line(), true
+		}
+		// compare against the match's own content rather than its line
+		// number, so re-filtering (which renumbers matches but doesn't
+		// necessarily change which one is highlighted) doesn't spuriously
+		// re-trigger the preview command
+		if have && (!l.previewedSet || line != l.previewed) {
+			l.previewed, l.previewedSet = line, true
+			l.preview.Update(targets[l.currentLine-1], l.QueryString(), l.currentLine)
+		} else if !have {
+			l.previewedSet = false
+		}
+
+		switch l.preview.position {
+		case PreviewWindowPositionLeft:
+			l.preview.Draw(0, l.preview.reservedColumns(width), perPage)
+		case PreviewWindowPositionRight:
+			pw := l.preview.reservedColumns(width)
+			l.preview.Draw(width-pw, pw, perPage)
+		default: // top, bottom: the preview spans the full screen width
+			l.preview.Draw(0, width, l.preview.reservedRows(height))
+		}
+	}
 
 	if err := screen.Flush(); err != nil {
 		return
 	}
 }
 
-func linesPerPage() int {
+// linesPerPage returns how many lines are available to the ListArea,
+// after accounting for the prompt, status bar, and (if configured) the
+// space reserved by the preview pane and the sticky header
+func (l *BasicLayout) linesPerPage() int {
 	_, height := screen.Size()
-	return height - 2 // list area is always the display area - 2 lines for prompt and status
+	perPage := height - 2 // list area is always the display area - 2 lines for prompt and status
+
+	if normalizedInfoStyle(l.config.InfoStyle) == "default" {
+		perPage--
+	}
+
+	if l.preview != nil {
+		perPage -= l.preview.reservedRows(height)
+	}
+
+	if l.header != nil {
+		perPage -= l.header.Height()
+	}
+
+	return perPage
+}
+
+// Jump always returns jump mode to JumpDisabled, mirroring fzf's
+// cancel-on-any-key behavior: an unlabeled key is treated as "never
+// mind" rather than a retry. It moves the cursor to the line labeled
+// r on the currently displayed page and reports true if the accept
+// action should also be fired (JumpAcceptEnabled); if r did not match
+// a visible label, it reports false and the cursor is left untouched
+func (l *BasicLayout) Jump(r rune) bool {
+	idx, ok := l.list.JumpIndex(r)
+	accept := l.jumping == JumpAcceptEnabled
+	l.jumping = JumpDisabled
+	if !ok {
+		return false
+	}
+
+	l.currentLine = idx
+
+	return accept
 }
 
 // MovePage moves the cursor
@@ -445,9 +1422,9 @@ func (l *BasicLayout) MovePage(p PagingRequest) {
 		case ToLineBelow:
 			l.currentLine++
 		case ToScrollPageDown:
-			l.currentLine += linesPerPage()
+			l.currentLine += l.linesPerPage()
 		case ToScrollPageUp:
-			l.currentLine -= linesPerPage()
+			l.currentLine -= l.linesPerPage()
 		}
 	} else {
 		switch p {
@@ -456,12 +1433,28 @@ func (l *BasicLayout) MovePage(p PagingRequest) {
 		case ToLineBelow:
 			l.currentLine--
 		case ToScrollPageDown:
-			l.currentLine -= linesPerPage()
+			l.currentLine -= l.linesPerPage()
 		case ToScrollPageUp:
-			l.currentLine += linesPerPage()
+			l.currentLine += l.linesPerPage()
 		}
 	}
 
+	if l.preview != nil {
+		switch p {
+		case PreviewScrollUp:
+			l.preview.ScrollUp()
+		case PreviewScrollDown:
+			l.preview.ScrollDown()
+		}
+	}
+
+	switch p {
+	case ToScrollLeft:
+		l.list.ScrollLeft()
+	case ToScrollRight:
+		l.list.ScrollRight()
+	}
+
 	if l.currentLine < 1 {
 		if l.current != nil {
 			// Go to last page, if possible
@@ -0,0 +1,94 @@
+package peco
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumnOffsets(t *testing.T) {
+	offsets := columnOffsets("a中c") // 'a' + a double-width CJK rune + 'c'
+	want := []int{0, 1, 1, 1, 3, 4}
+	if !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("columnOffsets(%q) = %v, want %v", "a中c", offsets, want)
+	}
+}
+
+func TestByteIndexForColumn(t *testing.T) {
+	offsets := columnOffsets("a中c")
+
+	tests := []struct {
+		col  int
+		want int
+	}{
+		{0, 0},
+		{1, 1},
+		{2, 4}, // column 2 falls inside the double-width rune; snaps to its end
+		{3, 4},
+		{4, 5},
+		{100, 5}, // past the end of the line
+	}
+	for _, tt := range tests {
+		if got := byteIndexForColumn(offsets, tt.col); got != tt.want {
+			t.Errorf("byteIndexForColumn(col=%d) = %d, want %d", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestClipMatches(t *testing.T) {
+	matches := [][]int{{0, 3}, {5, 8}, {10, 12}}
+
+	if got := clipMatches(nil, 0, 10); got != nil {
+		t.Errorf("clipMatches(nil, ...) = %v, want nil", got)
+	}
+
+	got := clipMatches(matches, 2, 11)
+	want := [][]int{{2, 3}, {5, 8}, {10, 11}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clipMatches(%v, 2, 11) = %v, want %v", matches, got, want)
+	}
+
+	// a window that excludes every match entirely
+	if got := clipMatches(matches, 100, 200); got != nil {
+		t.Errorf("clipMatches with a non-overlapping window = %v, want nil", got)
+	}
+}
+
+func TestVisualRows(t *testing.T) {
+	tests := []struct {
+		line       string
+		availWidth int
+		want       int
+	}{
+		{"short", 10, 1},
+		{"0123456789", 5, 2},
+		{"01234567890", 5, 3},
+		{"", 5, 1},
+		{"anything", 0, 1}, // guard against a degenerate/zero width
+	}
+	for _, tt := range tests {
+		if got := visualRows(tt.line, tt.availWidth); got != tt.want {
+			t.Errorf("visualRows(%q, %d) = %d, want %d", tt.line, tt.availWidth, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewAreaReservedCells(t *testing.T) {
+	tests := []struct {
+		size  string
+		total int
+		want  int
+	}{
+		{"50%", 100, 50},
+		{"150%", 100, 100}, // clamped to the total
+		{"0%", 100, 0},
+		{"20", 100, 20},
+		{"1000", 100, 100}, // clamped to the total
+		{"bogus", 100, 0},
+	}
+	for _, tt := range tests {
+		p := &PreviewArea{size: tt.size}
+		if got := p.reservedCells(tt.total); got != tt.want {
+			t.Errorf("reservedCells(size=%q, total=%d) = %d, want %d", tt.size, tt.total, got, tt.want)
+		}
+	}
+}